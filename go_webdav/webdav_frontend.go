@@ -2,6 +2,7 @@ package main
 
 //import "fmt"
 import ("os"; "os/signal"; "syscall")
+import "flag"
 import "log"
 import "golang.org/x/net/webdav"
 import "net/http"
@@ -10,29 +11,55 @@ import "sync"
 
 
 // Temporarily hardcoded
-var socketPath = "/tmp/wlfuse.sock"
+var socketPath = wildland_fs.DefaultSocketPath
+
+var lockSystemFlag = flag.String("lock-system", "wildland",
+	"lock system to use: \"wildland\" persists locks through Wildland, \"mem\" keeps them in-process only")
 
 func main() {
+	flag.Parse()
 	setupTerminationHandler()
-	fs := wildland_fs.WildlandFS()
+	fs := wildland_fs.WildlandFS(socketPath)
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
+
+	// Connect to the worker before anything that talks to the bridge (the
+	// wildland lock system reads its lock file through it on construction),
+	// so we don't build those on top of a fs.wl.client that isn't up yet.
+	fs.Start(wg)
+
+	var lockSystem webdav.LockSystem
+	switch *lockSystemFlag {
+	case "wildland":
+		lockSystem = wildland_fs.NewWildlandLS(fs)
+	case "mem":
+		lockSystem = webdav.NewMemLS()
+	default:
+		log.Fatal("unknown -lock-system: ", *lockSystemFlag)
+	}
+
 	server := &webdav.Handler {
 		  FileSystem: fs,
-			LockSystem: webdav.NewMemLS(),
+			LockSystem: lockSystem,
 		}
 
+	tokenPath := os.Getenv("XDG_RUNTIME_DIR") + "/wlfuse.token"
+	token, err := loadOrCreateToken(tokenPath)
+	if err != nil {
+		log.Fatal("failed to set up admin token: ", err)
+	}
+	http.Handle("/_admin/", fs.AdminHandler(token))
 
 	http.HandleFunc("/", func(w http.ResponseWriter,
 		req *http.Request) {
 		if  req.Method == "GET" {
-			log.Println("SHOULD NOT HAPPEN! listing for: ", 
-				req.URL.Path) 
+			log.Println("SHOULD NOT HAPPEN! listing for: ",
+				req.URL.Path)
 			return
 		}
 		server.ServeHTTP(w, req)
 	})
-	
+
 	http_fn := func() {
 		log.Println("webdav server begin to listen")
 		log.Println(http.ListenAndServe(":8080", nil))
@@ -40,7 +67,6 @@ func main() {
 		wg.Done()
 	}
 	go  http_fn()
-	go fs.Start(wg)
 	wg.Wait()
 	log.Println("wait group does not wait anymore")
 }
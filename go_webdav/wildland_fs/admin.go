@@ -0,0 +1,151 @@
+package wildland_fs
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+)
+
+/*
+AdminHandler exposes a token-authenticated /_admin/ API for filesystem
+introspection and cache control, so operators have a debug surface
+without having to speak WebDAV.
+*/
+
+type adminEntry struct {
+	Name  string    `json:"name"`
+	Size  int64     `json:"size"`
+	Mode  string    `json:"mode"`
+	MTime time.Time `json:"mtime"`
+	IsDir bool      `json:"is_dir"`
+}
+
+func toAdminEntry(info os.FileInfo) adminEntry {
+	return adminEntry{
+		Name:  info.Name(),
+		Size:  info.Size(),
+		Mode:  info.Mode().String(),
+		MTime: info.ModTime(),
+		IsDir: info.IsDir(),
+	}
+}
+
+type pathRequest struct {
+	Path string `json:"path"`
+}
+
+// AdminHandler returns an http.Handler serving fs/stat, fs/readdir,
+// fs/invalidate, fs/reconnect and healthz under the /_admin/ prefix,
+// rejecting any request that does not carry the WL-Token shared secret.
+func (fs *wildland_fs) AdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_admin/fs/stat", fs.handleAdminStat)
+	mux.HandleFunc("/_admin/fs/readdir", fs.handleAdminReaddir)
+	mux.HandleFunc("/_admin/fs/invalidate", fs.handleAdminInvalidate)
+	mux.HandleFunc("/_admin/fs/reconnect", fs.handleAdminReconnect)
+	mux.HandleFunc("/_admin/healthz", fs.handleAdminHealthz)
+	return requireToken(token, mux)
+}
+
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("WL-Token")), []byte(token)) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, "missing or invalid WL-Token")
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func decodePathRequest(w http.ResponseWriter, req *http.Request) (string, bool) {
+	var body pathRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return "", false
+	}
+	return body.Path, true
+}
+
+func (fs *wildland_fs) handleAdminStat(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeAdminError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	path, ok := decodePathRequest(w, req)
+	if !ok {
+		return
+	}
+	info, err := fs.Stat(context.Background(), path)
+	if err != nil {
+		writeAdminError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(toAdminEntry(info))
+}
+
+func (fs *wildland_fs) handleAdminReaddir(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeAdminError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	path, ok := decodePathRequest(w, req)
+	if !ok {
+		return
+	}
+	objs, err := fs.Readdir(path)
+	if err != nil {
+		writeAdminError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	entries := make([]adminEntry, 0, len(objs))
+	for _, obj := range objs {
+		entries = append(entries, toAdminEntry(obj))
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (fs *wildland_fs) handleAdminInvalidate(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeAdminError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	path, ok := decodePathRequest(w, req)
+	if !ok {
+		return
+	}
+	fs.Invalidate(path)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (fs *wildland_fs) handleAdminReconnect(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeAdminError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	if err := fs.Reconnect(); err != nil {
+		writeAdminError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (fs *wildland_fs) handleAdminHealthz(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeAdminError(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+	if !fs.Healthy() {
+		writeAdminError(w, http.StatusServiceUnavailable, "bridge not connected")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
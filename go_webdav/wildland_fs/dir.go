@@ -14,7 +14,7 @@ func (dir wildland_dir)Readdir(count int)([]fs.FileInfo, error) {
 	// For now just return an empty slice and indicate
 	// end of listing
 	log.Println("called readdir for ", dir.name)
-	if objs, err := dir.fs.Readdir(dir.name); err != nil {
+	if objs, err := dir.fs.Readdir(dir.Path()); err != nil {
 		return nil, err
 	} else {
 		var err error
@@ -37,7 +37,13 @@ func (dir wildland_dir)Read(b []byte) (int, error) {
 }
 
 func (dir wildland_dir)Seek(offset int64, whence int) (int64, error) {
-	return -1, errors.New("seek makes no sense for directories")
+	// Readdir always re-fetches the full listing from Wildland, so the
+	// only seek that makes sense for a directory is rewinding to the
+	// start of the enumeration.
+	if offset != 0 || whence != io.SeekStart {
+		return -1, errors.New("seek makes no sense for directories")
+	}
+	return 0, nil
 }
 
 
@@ -54,6 +60,10 @@ func (dir wildland_dir)Write(b []byte) (int, error) {
 	return 0, errors.New("unimplemented")
 }
 
+func (dir wildland_dir)Name() string {
+	return dir.name
+}
+
 func (dir wildland_dir)IsDir() bool {
 	return true
 }
@@ -0,0 +1,346 @@
+package wildland_fs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	wire "wildland.io/webdav_frontend/wildland_fs/rpc"
+)
+
+/*
+fakeWorker is an in-memory stand-in for the Python wildland.go.fs worker.
+It speaks the same length-prefixed JSON-RPC dialect as the real bridge
+(see rpc.Client), so it is driven over a real Unix socket rather than by
+swapping out an interface, which keeps the test honest about the wire
+format.
+*/
+
+var (
+	errFakeNotExist   = errors.New("fake bridge: no such path")
+	errFakeExist      = errors.New("fake bridge: path already exists")
+	errFakePermission = errors.New("fake bridge: permission denied")
+)
+
+type fakeFile struct {
+	isDir bool
+	data  []byte
+}
+
+type fakeWorker struct {
+	mu      sync.Mutex
+	files   map[string]*fakeFile
+	handles map[uint64]string
+	nextH   uint64
+}
+
+func newFakeWorker() *fakeWorker {
+	return &fakeWorker{
+		files:   map[string]*fakeFile{"/": {isDir: true}},
+		handles: map[uint64]string{},
+	}
+}
+
+func (w *fakeWorker) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go w.serveConn(conn)
+	}
+}
+
+func (w *fakeWorker) serveConn(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		var length uint32
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return
+		}
+
+		var req wire.Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return
+		}
+
+		resp := w.dispatch(&req)
+		respBody, err := json.Marshal(resp)
+		if err != nil {
+			return
+		}
+		var lenPrefix [4]byte
+		binary.BigEndian.PutUint32(lenPrefix[:], uint32(len(respBody)))
+		if _, err := conn.Write(lenPrefix[:]); err != nil {
+			return
+		}
+		if _, err := conn.Write(respBody); err != nil {
+			return
+		}
+	}
+}
+
+func (w *fakeWorker) dispatch(req *wire.Request) *wire.Response {
+	resp := &wire.Response{ID: req.ID}
+
+	var result interface{}
+	var err error
+
+	switch req.Method {
+	case "readdir":
+		var p readdirParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			result, err = w.readdir(p.Path)
+		}
+	case "stat":
+		var p statParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			result, err = w.stat(p.Path)
+		}
+	case "open":
+		var p openParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			result, err = w.open(p.Path, p.Flags)
+		}
+	case "read":
+		var p readParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			result, err = w.read(p.Handle, p.Offset, p.Size)
+		}
+	case "write":
+		var p writeParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			result, err = w.write(p.Handle, p.Offset, p.Data)
+		}
+	case "close":
+		var p handleParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			err = w.close(p.Handle)
+		}
+	case "mkdir":
+		var p mkdirParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			err = w.mkdir(p.Path)
+		}
+	case "rename":
+		var p renameParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			err = w.rename(p.OldPath, p.NewPath)
+		}
+	case "unlink":
+		var p unlinkParams
+		err = json.Unmarshal(req.Params, &p)
+		if err == nil {
+			err = w.unlink(p.Path)
+		}
+	default:
+		err = errors.New("fake bridge: unknown method " + req.Method)
+	}
+
+	if err != nil {
+		resp.Error = toWireError(err)
+		return resp
+	}
+	if result != nil {
+		raw, merr := json.Marshal(result)
+		if merr != nil {
+			resp.Error = &wire.Error{Code: 1, Message: merr.Error()}
+			return resp
+		}
+		resp.Result = raw
+	}
+	return resp
+}
+
+func toWireError(err error) *wire.Error {
+	switch {
+	case errors.Is(err, errFakeNotExist):
+		return &wire.Error{Code: rpcErrNotExist, Message: err.Error()}
+	case errors.Is(err, errFakeExist):
+		return &wire.Error{Code: rpcErrExist, Message: err.Error()}
+	case errors.Is(err, errFakePermission):
+		return &wire.Error{Code: rpcErrPermission, Message: err.Error()}
+	default:
+		return &wire.Error{Code: 1, Message: err.Error()}
+	}
+}
+
+func (w *fakeWorker) readdir(path string) ([]Entry, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if f, ok := w.files[path]; !ok || !f.isDir {
+		return nil, errFakeNotExist
+	}
+
+	prefix := path
+	if prefix != "/" {
+		prefix += "/"
+	}
+
+	var entries []Entry
+	for p, f := range w.files {
+		if p == path {
+			continue
+		}
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(p, prefix)
+		if strings.Contains(rest, "/") {
+			continue // not a direct child
+		}
+		entries = append(entries, entryFor(rest, f))
+	}
+	return entries, nil
+}
+
+func (w *fakeWorker) stat(path string) (Entry, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	f, ok := w.files[path]
+	if !ok {
+		return Entry{}, errFakeNotExist
+	}
+	return entryFor(baseName(path), f), nil
+}
+
+func entryFor(name string, f *fakeFile) Entry {
+	mode := os.FileMode(0644)
+	if f.isDir {
+		mode = os.ModeDir | 0755
+	}
+	return Entry{Name: name, Mode: mode, Size: int64(len(f.data))}
+}
+
+func baseName(path string) string {
+	if path == "/" {
+		return "/"
+	}
+	if idx := strings.LastIndex(path, "/"); idx >= 0 {
+		return path[idx+1:]
+	}
+	return path
+}
+
+func (w *fakeWorker) open(path string, flags int) (openResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	f, ok := w.files[path]
+	if !ok {
+		if flags&os.O_CREATE == 0 {
+			return openResult{}, errFakeNotExist
+		}
+		f = &fakeFile{}
+		w.files[path] = f
+	} else if flags&os.O_CREATE != 0 && flags&os.O_EXCL != 0 {
+		return openResult{}, errFakeExist
+	}
+
+	if flags&os.O_TRUNC != 0 {
+		f.data = nil
+	}
+
+	w.nextH++
+	h := w.nextH
+	w.handles[h] = path
+	return openResult{Handle: h}, nil
+}
+
+func (w *fakeWorker) read(handle uint64, offset int64, size int) (readResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	path, ok := w.handles[handle]
+	if !ok {
+		return readResult{}, errFakeNotExist
+	}
+	f := w.files[path]
+	if offset >= int64(len(f.data)) {
+		return readResult{Data: []byte{}}, nil
+	}
+	end := offset + int64(size)
+	if end > int64(len(f.data)) {
+		end = int64(len(f.data))
+	}
+	return readResult{Data: append([]byte{}, f.data[offset:end]...)}, nil
+}
+
+func (w *fakeWorker) write(handle uint64, offset int64, data []byte) (writeResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	path, ok := w.handles[handle]
+	if !ok {
+		return writeResult{}, errFakeNotExist
+	}
+	f := w.files[path]
+	end := offset + int64(len(data))
+	if end > int64(len(f.data)) {
+		grown := make([]byte, end)
+		copy(grown, f.data)
+		f.data = grown
+	}
+	copy(f.data[offset:], data)
+	return writeResult{Written: len(data)}, nil
+}
+
+func (w *fakeWorker) close(handle uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.handles, handle)
+	return nil
+}
+
+func (w *fakeWorker) mkdir(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.files[path]; ok {
+		return errFakeExist
+	}
+	w.files[path] = &fakeFile{isDir: true}
+	return nil
+}
+
+func (w *fakeWorker) rename(oldPath, newPath string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f, ok := w.files[oldPath]
+	if !ok {
+		return errFakeNotExist
+	}
+	delete(w.files, oldPath)
+	w.files[newPath] = f
+	return nil
+}
+
+func (w *fakeWorker) unlink(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.files[path]; !ok {
+		return errFakeNotExist
+	}
+	delete(w.files, path)
+	return nil
+}
@@ -0,0 +1,92 @@
+package wildland_fs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// wildland_file is the webdav.File implementation for regular files. The
+// Inode tree holds one canonical wildland_file per path (handle == 0, used
+// for Stat/Readdir-style metadata), while OpenFile hands out a fresh copy
+// carrying its own handle and offset for each open session.
+type wildland_file struct {
+	fs_object
+	handle uint64
+	offset int64
+	size   int64
+}
+
+func (f *wildland_file) Read(p []byte) (int, error) {
+	data, err := f.fs.wl.read(f.handle, f.offset, len(p))
+	if err != nil {
+		return 0, mapBridgeError(err)
+	}
+	n := copy(p, data)
+	f.offset += int64(n)
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func (f *wildland_file) Write(p []byte) (int, error) {
+	n, err := f.fs.wl.write(f.handle, f.offset, p)
+	if err != nil {
+		return n, mapBridgeError(err)
+	}
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *wildland_file) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		f.offset = offset
+	case io.SeekCurrent:
+		f.offset += offset
+	case io.SeekEnd:
+		info, err := f.Stat()
+		if err != nil {
+			return -1, err
+		}
+		f.offset = info.Size() + offset
+	default:
+		return -1, errors.New("wildland_file: invalid whence")
+	}
+	return f.offset, nil
+}
+
+func (f *wildland_file) Close() error {
+	return mapBridgeError(f.fs.wl.close(f.handle))
+}
+
+func (f *wildland_file) Readdir(count int) ([]fs.FileInfo, error) {
+	return nil, errors.New("readdir makes no sense for files")
+}
+
+func (f *wildland_file) Stat() (fs.FileInfo, error) {
+	entry, err := f.fs.wl.stat(f.Path())
+	if err != nil {
+		return nil, mapBridgeError(err)
+	}
+	f.size = entry.Size
+	return f, nil
+}
+
+func (f *wildland_file) IsDir() bool {
+	return false
+}
+
+func (f *wildland_file) Size() int64 {
+	return f.size
+}
+
+func (f *wildland_file) Mode() fs.FileMode {
+	return 0644
+}
+
+func (f *wildland_file) ModTime() time.Time {
+	return time.Now()
+}
@@ -10,6 +10,17 @@ type fs_object struct {
 	i_fs_object
 	fs *wildland_fs
 	name string
+	ino *Inode
+}
+
+// Path reconstructs this object's path by walking its Inode's parent
+// links up to the filesystem root, so it stays correct across renames
+// instead of freezing whatever name the object was created with.
+func (o *fs_object) Path() string {
+	if o.fs == nil || o.ino == nil {
+		return o.name
+	}
+	return o.ino.Path(o.fs.root)
 }
 
 // Blessable implementation
@@ -0,0 +1,143 @@
+package wildland_fs
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Inode ports the node model from go-fuse: a tree of nodes identified by a
+stable ID (StableAttr) rather than by path, so that a rename does not
+invalidate anything holding a reference to the node it moved. This
+replaces the old flat wildland_fs.pmap, which was keyed by path, had no
+parent/child links and no eviction.
+*/
+
+// StableAttr uniquely identifies an Inode across renames.
+type StableAttr struct {
+	Ino  uint64
+	Mode os.FileMode
+	Gen  uint64
+}
+
+// FSNode is the behaviour attached to an Inode: the Wildland-backed file
+// or directory implementation that actually talks to the bridge.
+type FSNode interface {
+	i_fs_object
+}
+
+// parentEntry is one (name, parent) edge pointing back from a child to a
+// directory it is linked under. A child can in principle have more than
+// one parent entry (hardlinks); Wildland does not support those yet, but
+// the map shape leaves room for it.
+type parentEntry struct {
+	name   string
+	parent *Inode
+}
+
+// Inode is one node of the in-memory filesystem tree.
+type Inode struct {
+	StableAttr
+	ops FSNode
+
+	mu       sync.Mutex
+	parents  map[parentEntry]struct{}
+	children map[string]*Inode
+}
+
+var inoCounter uint64
+
+func nextIno() uint64 {
+	return atomic.AddUint64(&inoCounter, 1)
+}
+
+// NewPersistentInode creates an Inode wrapping ops with the given
+// StableAttr, allocating a fresh Ino if attr.Ino is zero. "Persistent"
+// mirrors go-fuse's naming: the Inode stays in the tree (and in `ops`'s
+// memory) until explicitly removed, rather than being synthesized
+// per-lookup.
+func NewPersistentInode(ops FSNode, attr StableAttr) *Inode {
+	if attr.Ino == 0 {
+		attr.Ino = nextIno()
+	}
+	return &Inode{
+		StableAttr: attr,
+		ops:        ops,
+		parents:    make(map[parentEntry]struct{}),
+		children:   make(map[string]*Inode),
+	}
+}
+
+// AddChild links child under this Inode as name, recording the reverse
+// parent edge so the child can still report Path() after being moved.
+func (n *Inode) AddChild(name string, child *Inode) {
+	n.mu.Lock()
+	n.children[name] = child
+	n.mu.Unlock()
+
+	child.mu.Lock()
+	child.parents[parentEntry{name: name, parent: n}] = struct{}{}
+	child.mu.Unlock()
+}
+
+// RmChild unlinks name from this Inode, dropping the corresponding parent
+// edge on the child (if any), and returns the child that was removed.
+func (n *Inode) RmChild(name string) *Inode {
+	n.mu.Lock()
+	child, ok := n.children[name]
+	if ok {
+		delete(n.children, name)
+	}
+	n.mu.Unlock()
+
+	if ok {
+		child.mu.Lock()
+		delete(child.parents, parentEntry{name: name, parent: n})
+		child.mu.Unlock()
+	}
+	return child
+}
+
+// GetChild looks up name among this Inode's children, or returns nil.
+func (n *Inode) GetChild(name string) *Inode {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.children[name]
+}
+
+// Children returns a snapshot of this Inode's name -> child map.
+func (n *Inode) Children() map[string]*Inode {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make(map[string]*Inode, len(n.children))
+	for name, child := range n.children {
+		out[name] = child
+	}
+	return out
+}
+
+// Path reconstructs a path from root down to this Inode by following
+// parent edges. Unlike a path stored on the node itself, this stays
+// correct across renames because AddChild/RmChild keep the edges live.
+func (n *Inode) Path(root *Inode) string {
+	if n == root {
+		return "/"
+	}
+
+	n.mu.Lock()
+	var entry parentEntry
+	for pe := range n.parents {
+		entry = pe
+		break
+	}
+	n.mu.Unlock()
+
+	if entry.parent == nil {
+		return "" // detached: removed from the tree but still referenced
+	}
+	if entry.parent == root {
+		return "/" + entry.name
+	}
+	return entry.parent.Path(root) + "/" + entry.name
+}
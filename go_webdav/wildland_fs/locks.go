@@ -0,0 +1,460 @@
+package wildland_fs
+
+import (
+	"container/heap"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+/*
+WildlandLS is a webdav.LockSystem that keeps its lock table in a
+Wildland-hosted file instead of only in memory, so LOCK tokens survive a
+restart and are shared between frontend instances backed by the same
+container. The in-memory structure (byName/byToken/byExpiry, held-lock
+refcounting) mirrors golang.org/x/net/webdav's memLS; what's new here is
+loading that table from .wl-locks.json on startup and rewriting it through
+the bridge on every mutation.
+*/
+
+// locksFilePath is where the lock table is stored, at the root of the
+// mounted container.
+const locksFilePath = "/.wl-locks.json"
+
+// expirySweepInterval is how often a background goroutine reaps and
+// persists expired locks even if nothing calls into the LockSystem in the
+// meantime.
+const expirySweepInterval = 30 * time.Second
+
+// lockRecord is the on-disk representation of one lock.
+type lockRecord struct {
+	Token     string    `json:"token"`
+	Root      string    `json:"root"`
+	ZeroDepth bool      `json:"zero_depth"`
+	OwnerXML  string    `json:"owner_xml"`
+	Expiry    time.Time `json:"expiry,omitempty"`
+	NoExpiry  bool      `json:"no_expiry"`
+}
+
+type wildlandLSNode struct {
+	details       webdav.LockDetails
+	token         string
+	refCount      int
+	expiry        time.Time
+	noExpiry      bool
+	byExpiryIndex int
+	held          bool
+}
+
+// WildlandLS implements webdav.LockSystem on top of a Wildland-hosted
+// lock file.
+type WildlandLS struct {
+	fs *wildland_fs
+
+	mu       sync.Mutex
+	byName   map[string]*wildlandLSNode
+	byToken  map[string]*wildlandLSNode
+	gen      uint64
+	byExpiry lsByExpiry
+}
+
+// NewWildlandLS creates a WildlandLS backed by fs, rebuilding its
+// in-memory index from any lock table already present in the container
+// and starting a background sweep for expired locks.
+func NewWildlandLS(fs *wildland_fs) webdav.LockSystem {
+	ls := &WildlandLS{
+		fs:      fs,
+		byName:  make(map[string]*wildlandLSNode),
+		byToken: make(map[string]*wildlandLSNode),
+		gen:     uint64(time.Now().Unix()),
+	}
+	ls.loadFromWildland()
+	go ls.sweepLoop()
+	return ls
+}
+
+func (ls *WildlandLS) nextToken() string {
+	ls.gen++
+	return "opaquelocktoken:" + strconv.FormatUint(ls.gen, 10)
+}
+
+func (ls *WildlandLS) collectExpiredNodes(now time.Time) {
+	changed := false
+	for len(ls.byExpiry) > 0 {
+		if now.Before(ls.byExpiry[0].expiry) {
+			break
+		}
+		ls.remove(ls.byExpiry[0])
+		changed = true
+	}
+	if changed {
+		ls.persistLocked()
+	}
+}
+
+func (ls *WildlandLS) Confirm(now time.Time, name0, name1 string, conditions ...webdav.Condition) (func(), error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.collectExpiredNodes(now)
+
+	var n0, n1 *wildlandLSNode
+	if name0 != "" {
+		if n0 = ls.lookup(slashClean(name0), conditions...); n0 == nil {
+			return nil, webdav.ErrConfirmationFailed
+		}
+	}
+	if name1 != "" {
+		if n1 = ls.lookup(slashClean(name1), conditions...); n1 == nil {
+			return nil, webdav.ErrConfirmationFailed
+		}
+	}
+	if n1 == n0 {
+		n1 = nil
+	}
+
+	if n0 != nil {
+		ls.hold(n0)
+	}
+	if n1 != nil {
+		ls.hold(n1)
+	}
+	return func() {
+		ls.mu.Lock()
+		defer ls.mu.Unlock()
+		if n1 != nil {
+			ls.unhold(n1)
+		}
+		if n0 != nil {
+			ls.unhold(n0)
+		}
+	}, nil
+}
+
+// lookup returns the node that locks name, provided it matches one of the
+// given conditions and is not already held by another Confirm call. n may
+// be a parent of name if n is an infinite-depth lock.
+func (ls *WildlandLS) lookup(name string, conditions ...webdav.Condition) (n *wildlandLSNode) {
+	for _, c := range conditions {
+		n = ls.byToken[c.Token]
+		if n == nil || n.held {
+			continue
+		}
+		if name == n.details.Root {
+			return n
+		}
+		if n.details.ZeroDepth {
+			continue
+		}
+		if n.details.Root == "/" || strings.HasPrefix(name, n.details.Root+"/") {
+			return n
+		}
+	}
+	return nil
+}
+
+func (ls *WildlandLS) hold(n *wildlandLSNode) {
+	n.held = true
+	if !n.noExpiry && n.byExpiryIndex >= 0 {
+		heap.Remove(&ls.byExpiry, n.byExpiryIndex)
+	}
+}
+
+func (ls *WildlandLS) unhold(n *wildlandLSNode) {
+	n.held = false
+	if !n.noExpiry {
+		heap.Push(&ls.byExpiry, n)
+	}
+}
+
+func (ls *WildlandLS) Create(now time.Time, details webdav.LockDetails) (string, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.collectExpiredNodes(now)
+	details.Root = slashClean(details.Root)
+
+	if !ls.canCreate(details.Root, details.ZeroDepth) {
+		return "", webdav.ErrLocked
+	}
+	n := ls.create(details.Root)
+	n.token = ls.nextToken()
+	ls.byToken[n.token] = n
+	n.details = details
+	if n.details.Duration >= 0 {
+		n.expiry = now.Add(n.details.Duration)
+		heap.Push(&ls.byExpiry, n)
+	} else {
+		n.noExpiry = true
+	}
+	ls.persistLocked()
+	return n.token, nil
+}
+
+func (ls *WildlandLS) Refresh(now time.Time, token string, duration time.Duration) (webdav.LockDetails, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.collectExpiredNodes(now)
+
+	n := ls.byToken[token]
+	if n == nil {
+		return webdav.LockDetails{}, webdav.ErrNoSuchLock
+	}
+	if n.held {
+		return webdav.LockDetails{}, webdav.ErrLocked
+	}
+	if !n.noExpiry && n.byExpiryIndex >= 0 {
+		heap.Remove(&ls.byExpiry, n.byExpiryIndex)
+	}
+	n.details.Duration = duration
+	if n.details.Duration >= 0 {
+		n.noExpiry = false
+		n.expiry = now.Add(n.details.Duration)
+		heap.Push(&ls.byExpiry, n)
+	} else {
+		n.noExpiry = true
+	}
+	ls.persistLocked()
+	return n.details, nil
+}
+
+func (ls *WildlandLS) Unlock(now time.Time, token string) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.collectExpiredNodes(now)
+
+	n := ls.byToken[token]
+	if n == nil {
+		return webdav.ErrNoSuchLock
+	}
+	if n.held {
+		return webdav.ErrLocked
+	}
+	ls.remove(n)
+	ls.persistLocked()
+	return nil
+}
+
+func (ls *WildlandLS) canCreate(name string, zeroDepth bool) bool {
+	return lsWalkToRoot(name, func(name0 string, first bool) bool {
+		n := ls.byName[name0]
+		if n == nil {
+			return true
+		}
+		if first {
+			if n.token != "" {
+				return false // the target node is already locked
+			}
+			if !zeroDepth {
+				return false // a descendant of the target is locked
+			}
+		} else if n.token != "" && !n.details.ZeroDepth {
+			return false // an ancestor is locked with infinite depth
+		}
+		return true
+	})
+}
+
+func (ls *WildlandLS) create(name string) (ret *wildlandLSNode) {
+	lsWalkToRoot(name, func(name0 string, first bool) bool {
+		n := ls.byName[name0]
+		if n == nil {
+			n = &wildlandLSNode{
+				details:       webdav.LockDetails{Root: name0},
+				byExpiryIndex: -1,
+			}
+			ls.byName[name0] = n
+		}
+		n.refCount++
+		if first {
+			ret = n
+		}
+		return true
+	})
+	return ret
+}
+
+func (ls *WildlandLS) remove(n *wildlandLSNode) {
+	delete(ls.byToken, n.token)
+	n.token = ""
+	lsWalkToRoot(n.details.Root, func(name0 string, first bool) bool {
+		x := ls.byName[name0]
+		x.refCount--
+		if x.refCount == 0 {
+			delete(ls.byName, name0)
+		}
+		return true
+	})
+	if !n.noExpiry && n.byExpiryIndex >= 0 {
+		heap.Remove(&ls.byExpiry, n.byExpiryIndex)
+	}
+}
+
+// lsByExpiry is a min-heap of locks ordered by expiry, used to find
+// expired locks without scanning the whole lock table.
+type lsByExpiry []*wildlandLSNode
+
+func (h lsByExpiry) Len() int            { return len(h) }
+func (h lsByExpiry) Less(i, j int) bool  { return h[i].expiry.Before(h[j].expiry) }
+func (h lsByExpiry) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].byExpiryIndex = i
+	h[j].byExpiryIndex = j
+}
+
+func (h *lsByExpiry) Push(x interface{}) {
+	n := x.(*wildlandLSNode)
+	n.byExpiryIndex = len(*h)
+	*h = append(*h, n)
+}
+
+func (h *lsByExpiry) Pop() interface{} {
+	old := *h
+	i := len(old) - 1
+	n := old[i]
+	old[i] = nil
+	n.byExpiryIndex = -1
+	*h = old[:i]
+	return n
+}
+
+func lsWalkToRoot(name string, f func(name0 string, first bool) bool) bool {
+	for first := true; ; first = false {
+		if !f(name, first) {
+			return false
+		}
+		if name == "/" {
+			break
+		}
+		name = name[:strings.LastIndex(name, "/")]
+		if name == "" {
+			name = "/"
+		}
+	}
+	return true
+}
+
+func slashClean(name string) string {
+	if name == "" || name[0] != '/' {
+		name = "/" + name
+	}
+	return strings.TrimRight(name, "/")
+}
+
+// sweepLoop periodically reaps expired locks so they get persisted away
+// even when no WebDAV client happens to call in.
+func (ls *WildlandLS) sweepLoop() {
+	ticker := time.NewTicker(expirySweepInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		ls.mu.Lock()
+		ls.collectExpiredNodes(now)
+		ls.mu.Unlock()
+	}
+}
+
+// loadFromWildland rebuilds the in-memory index from the lock table
+// stored at locksFilePath, if any. A missing or corrupt file just means
+// starting with an empty lock table.
+func (ls *WildlandLS) loadFromWildland() {
+	data, err := ls.readLocksFile()
+	if err != nil {
+		log.Println("wildland locks: no existing lock table, starting empty:", err)
+		return
+	}
+
+	var records []lockRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		log.Println("wildland locks: corrupt lock table, starting empty:", err)
+		return
+	}
+
+	now := time.Now()
+	for _, rec := range records {
+		if !rec.NoExpiry && !now.Before(rec.Expiry) {
+			continue // already expired
+		}
+		n := ls.create(rec.Root)
+		n.token = rec.Token
+		n.details = webdav.LockDetails{
+			Root:      rec.Root,
+			OwnerXML:  rec.OwnerXML,
+			ZeroDepth: rec.ZeroDepth,
+		}
+		ls.byToken[n.token] = n
+		if rec.NoExpiry {
+			n.details.Duration = -1
+			n.noExpiry = true
+		} else {
+			n.details.Duration = rec.Expiry.Sub(now)
+			n.expiry = rec.Expiry
+			heap.Push(&ls.byExpiry, n)
+		}
+	}
+}
+
+// persistLocked rewrites the lock table file from the current in-memory
+// index. Callers must hold ls.mu.
+func (ls *WildlandLS) persistLocked() {
+	records := make([]lockRecord, 0, len(ls.byToken))
+	for token, n := range ls.byToken {
+		rec := lockRecord{
+			Token:     token,
+			Root:      n.details.Root,
+			ZeroDepth: n.details.ZeroDepth,
+			OwnerXML:  n.details.OwnerXML,
+			NoExpiry:  n.noExpiry,
+		}
+		if !n.noExpiry {
+			rec.Expiry = n.expiry
+		}
+		records = append(records, rec)
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		log.Println("wildland locks: failed to marshal lock table:", err)
+		return
+	}
+	if err := ls.writeLocksFile(data); err != nil {
+		log.Println("wildland locks: failed to persist lock table:", err)
+	}
+}
+
+func (ls *WildlandLS) readLocksFile() ([]byte, error) {
+	handle, err := ls.fs.wl.open(locksFilePath, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer ls.fs.wl.close(handle)
+
+	var buf []byte
+	var offset int64
+	for {
+		chunk, err := ls.fs.wl.read(handle, offset, 4096)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunk) == 0 {
+			break
+		}
+		buf = append(buf, chunk...)
+		offset += int64(len(chunk))
+	}
+	return buf, nil
+}
+
+func (ls *WildlandLS) writeLocksFile(data []byte) error {
+	handle, err := ls.fs.wl.open(locksFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	defer ls.fs.wl.close(handle)
+
+	_, err = ls.fs.wl.write(handle, 0, data)
+	return err
+}
@@ -0,0 +1,76 @@
+package wildland_fs
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// TestWildlandLSCreateConfirmUnlock exercises the basic LockSystem cycle
+// against the fake bridge: Create a lock, Confirm it, then Unlock it and
+// confirm a second Create on the same root succeeds afterwards.
+func TestWildlandLSCreateConfirmUnlock(t *testing.T) {
+	fsys, cleanup := newFakeBackedFS(t)
+	defer cleanup()
+
+	ls := NewWildlandLS(fsys).(*WildlandLS)
+	now := time.Now()
+
+	token, err := ls.Create(now, webdav.LockDetails{
+		Root:     "/foo",
+		Duration: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	release, err := ls.Confirm(now, "/foo", "", webdav.Condition{Token: token})
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	release()
+
+	if _, err := ls.Create(now, webdav.LockDetails{Root: "/foo", Duration: -1}); err != webdav.ErrLocked {
+		t.Fatalf("Create on still-locked root: got %v, want ErrLocked", err)
+	}
+
+	if err := ls.Unlock(now, token); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	token2, err := ls.Create(now, webdav.LockDetails{Root: "/foo", Duration: -1})
+	if err != nil {
+		t.Fatalf("Create after Unlock: %v", err)
+	}
+	if err := ls.Unlock(now, token2); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+}
+
+// TestWildlandLSPersistReload checks that a lock created against one
+// WildlandLS instance is visible to a second instance built fresh against
+// the same bridge, i.e. the .wl-locks.json round trip actually works.
+func TestWildlandLSPersistReload(t *testing.T) {
+	fsys, cleanup := newFakeBackedFS(t)
+	defer cleanup()
+
+	now := time.Now()
+	ls1 := NewWildlandLS(fsys).(*WildlandLS)
+	token, err := ls1.Create(now, webdav.LockDetails{
+		Root:     "/bar",
+		Duration: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	ls2 := NewWildlandLS(fsys).(*WildlandLS)
+	if _, err := ls2.Create(now, webdav.LockDetails{Root: "/bar", Duration: -1}); err != webdav.ErrLocked {
+		t.Fatalf("Create on reloaded root: got %v, want ErrLocked", err)
+	}
+
+	if err := ls2.Unlock(now, token); err != nil {
+		t.Fatalf("Unlock on reloaded instance: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package postest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// FileBasic covers create, write, stat, read back and unlink of a file.
+func FileBasic(t *testing.T, fsys webdav.FileSystem) {
+	ctx := context.Background()
+	content := []byte("hello wildland")
+
+	writeAndClose(t, mustCreate(t, fsys, "/basic"), content)
+
+	info, err := fsys.Stat(ctx, "/basic")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.IsDir() {
+		t.Fatalf("stat /basic: got a directory")
+	}
+	if info.Size() != int64(len(content)) {
+		t.Fatalf("stat size = %d, want %d", info.Size(), len(content))
+	}
+
+	f := mustOpen(t, fsys, "/basic", os.O_RDONLY)
+	got := readAll(t, f)
+	f.Close()
+	if !bytes.Equal(got, content) {
+		t.Fatalf("read back = %q, want %q", got, content)
+	}
+
+	if err := fsys.RemoveAll(ctx, "/basic"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if _, err := fsys.Stat(ctx, "/basic"); err == nil {
+		t.Fatalf("stat after RemoveAll: expected error, got none")
+	}
+}
+
+// AppendWrite checks that writes at an explicit offset at the current end
+// of file extend it rather than overwriting the start.
+func AppendWrite(t *testing.T, fsys webdav.FileSystem) {
+	writeAndClose(t, mustCreate(t, fsys, "/append"), []byte("abc"))
+
+	f := mustOpen(t, fsys, "/append", os.O_RDWR)
+	if _, err := f.Seek(3, io.SeekStart); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if _, err := f.Write([]byte("def")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	f.Close()
+
+	f = mustOpen(t, fsys, "/append", os.O_RDONLY)
+	got := readAll(t, f)
+	f.Close()
+	if string(got) != "abcdef" {
+		t.Fatalf("content = %q, want %q", got, "abcdef")
+	}
+}
+
+// OpenAt checks that reads and writes are relative to an explicitly set
+// offset rather than always starting at zero.
+func OpenAt(t *testing.T, fsys webdav.FileSystem) {
+	writeAndClose(t, mustCreate(t, fsys, "/openat"), []byte("0123456789"))
+
+	f := mustOpen(t, fsys, "/openat", os.O_RDONLY)
+	defer f.Close()
+
+	if _, err := f.Seek(5, io.SeekStart); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	buf := make([]byte, 3)
+	n, err := f.Read(buf)
+	if err != nil {
+		t.Fatalf("read at offset: %v", err)
+	}
+	if string(buf[:n]) != "567" {
+		t.Fatalf("read at offset 5 = %q, want %q", buf[:n], "567")
+	}
+}
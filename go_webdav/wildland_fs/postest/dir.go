@@ -0,0 +1,121 @@
+package postest
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// MkdirRmdir checks that a created directory shows up in Stat and then
+// disappears again after RemoveAll.
+func MkdirRmdir(t *testing.T, fsys webdav.FileSystem) {
+	ctx := context.Background()
+
+	if err := fsys.Mkdir(ctx, "/mkdir-rmdir", 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	info, err := fsys.Stat(ctx, "/mkdir-rmdir")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if !info.IsDir() {
+		t.Fatalf("stat /mkdir-rmdir: not a directory")
+	}
+
+	if err := fsys.RemoveAll(ctx, "/mkdir-rmdir"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if _, err := fsys.Stat(ctx, "/mkdir-rmdir"); err == nil {
+		t.Fatalf("stat after RemoveAll: expected error, got none")
+	}
+}
+
+// ReadDir checks that files created inside a directory are listed back by
+// Readdir.
+func ReadDir(t *testing.T, fsys webdav.FileSystem) {
+	ctx := context.Background()
+	if err := fsys.Mkdir(ctx, "/readdir", 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	writeAndClose(t, mustCreate(t, fsys, "/readdir/one"), []byte("1"))
+	writeAndClose(t, mustCreate(t, fsys, "/readdir/two"), []byte("2"))
+
+	dir := mustOpen(t, fsys, "/readdir", os.O_RDONLY)
+	defer dir.Close()
+
+	names := listNames(t, dir)
+	want := map[string]bool{"one": true, "two": true}
+	if len(names) != len(want) {
+		t.Fatalf("readdir returned %v, want entries for %v", names, want)
+	}
+	for _, n := range names {
+		if !want[n] {
+			t.Fatalf("unexpected entry %q in readdir result", n)
+		}
+	}
+}
+
+// ReadDirPicksUpCreate checks that a file created after a directory was
+// first listed shows up on a subsequent Readdir, i.e. the directory
+// listing is not a one-shot snapshot.
+func ReadDirPicksUpCreate(t *testing.T, fsys webdav.FileSystem) {
+	ctx := context.Background()
+	if err := fsys.Mkdir(ctx, "/readdir-live", 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	dir := mustOpen(t, fsys, "/readdir-live", os.O_RDONLY)
+	if len(listNames(t, dir)) != 0 {
+		t.Fatalf("expected empty directory before create")
+	}
+	dir.Close()
+
+	writeAndClose(t, mustCreate(t, fsys, "/readdir-live/late"), []byte("x"))
+
+	dir = mustOpen(t, fsys, "/readdir-live", os.O_RDONLY)
+	defer dir.Close()
+	names := listNames(t, dir)
+	if len(names) != 1 || names[0] != "late" {
+		t.Fatalf("readdir after create = %v, want [late]", names)
+	}
+}
+
+// DirSeek checks that Seek-ing a directory back to the start restarts the
+// Readdir enumeration.
+func DirSeek(t *testing.T, fsys webdav.FileSystem) {
+	ctx := context.Background()
+	if err := fsys.Mkdir(ctx, "/dirseek", 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	writeAndClose(t, mustCreate(t, fsys, "/dirseek/only"), []byte("x"))
+
+	dir := mustOpen(t, fsys, "/dirseek", os.O_RDONLY)
+	defer dir.Close()
+
+	first := listNames(t, dir)
+	if _, err := dir.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("seek to start: %v", err)
+	}
+	second := listNames(t, dir)
+
+	if len(first) != 1 || len(second) != 1 || first[0] != second[0] {
+		t.Fatalf("readdir before/after seek mismatch: %v vs %v", first, second)
+	}
+}
+
+func listNames(t *testing.T, dir webdav.File) []string {
+	t.Helper()
+	infos, err := dir.Readdir(-1)
+	if err != nil && err != io.EOF {
+		t.Fatalf("Readdir: %v", err)
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	return names
+}
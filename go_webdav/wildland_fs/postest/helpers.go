@@ -0,0 +1,47 @@
+package postest
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+func mustCreate(t *testing.T, fsys webdav.FileSystem, path string) webdav.File {
+	t.Helper()
+	f, err := fsys.OpenFile(context.Background(), path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatalf("create %q: %v", path, err)
+	}
+	return f
+}
+
+func mustOpen(t *testing.T, fsys webdav.FileSystem, path string, flag int) webdav.File {
+	t.Helper()
+	f, err := fsys.OpenFile(context.Background(), path, flag, 0644)
+	if err != nil {
+		t.Fatalf("open %q: %v", path, err)
+	}
+	return f
+}
+
+func writeAndClose(t *testing.T, f webdav.File, data []byte) {
+	t.Helper()
+	if _, err := f.Write(data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func readAll(t *testing.T, f webdav.File) []byte {
+	t.Helper()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,30 @@
+// Package postest is a POSIX/WebDAV compliance test harness modeled on
+// go-fuse's posixtest: each entry in All exercises a webdav.FileSystem
+// purely through that interface, so the same suite can run against an
+// in-memory fake bridge in unit tests and against a real Wildland worker
+// in integration tests.
+package postest
+
+import (
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// All is the full compliance suite, keyed by test name so callers can run
+// it with testing.T.Run(name, ...) and get per-case pass/fail reporting.
+var All = map[string]func(*testing.T, webdav.FileSystem){
+	"SymlinkReadlink":            SymlinkReadlink,
+	"FileBasic":                  FileBasic,
+	"TruncateFile":               TruncateFile,
+	"TruncateNoFile":             TruncateNoFile,
+	"MkdirRmdir":                 MkdirRmdir,
+	"RenameOverwriteDestExist":   RenameOverwriteDestExist,
+	"RenameOverwriteDestNoExist": RenameOverwriteDestNoExist,
+	"ReadDir":                    ReadDir,
+	"ReadDirPicksUpCreate":       ReadDirPicksUpCreate,
+	"AppendWrite":                AppendWrite,
+	"OpenAt":                     OpenAt,
+	"Fallocate":                  Fallocate,
+	"DirSeek":                    DirSeek,
+}
@@ -0,0 +1,55 @@
+package postest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// RenameOverwriteDestExist checks that renaming onto an existing
+// destination replaces its content.
+func RenameOverwriteDestExist(t *testing.T, fsys webdav.FileSystem) {
+	ctx := context.Background()
+	writeAndClose(t, mustCreate(t, fsys, "/rename-src-1"), []byte("source"))
+	writeAndClose(t, mustCreate(t, fsys, "/rename-dst-1"), []byte("destination"))
+
+	if err := fsys.Rename(ctx, "/rename-src-1", "/rename-dst-1"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	if _, err := fsys.Stat(ctx, "/rename-src-1"); err == nil {
+		t.Fatalf("stat source after rename: expected error, got none")
+	}
+
+	f := mustOpen(t, fsys, "/rename-dst-1", os.O_RDONLY)
+	got := readAll(t, f)
+	f.Close()
+	if !bytes.Equal(got, []byte("source")) {
+		t.Fatalf("dest content after rename = %q, want %q", got, "source")
+	}
+}
+
+// RenameOverwriteDestNoExist checks the simpler case of renaming onto a
+// path that does not exist yet.
+func RenameOverwriteDestNoExist(t *testing.T, fsys webdav.FileSystem) {
+	ctx := context.Background()
+	writeAndClose(t, mustCreate(t, fsys, "/rename-src-2"), []byte("payload"))
+
+	if err := fsys.Rename(ctx, "/rename-src-2", "/rename-dst-2"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	if _, err := fsys.Stat(ctx, "/rename-src-2"); err == nil {
+		t.Fatalf("stat source after rename: expected error, got none")
+	}
+
+	f := mustOpen(t, fsys, "/rename-dst-2", os.O_RDONLY)
+	got := readAll(t, f)
+	f.Close()
+	if !bytes.Equal(got, []byte("payload")) {
+		t.Fatalf("dest content after rename = %q, want %q", got, "payload")
+	}
+}
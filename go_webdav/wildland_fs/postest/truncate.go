@@ -0,0 +1,39 @@
+package postest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// TruncateFile checks that opening an existing file with O_TRUNC discards
+// its previous content.
+func TruncateFile(t *testing.T, fsys webdav.FileSystem) {
+	writeAndClose(t, mustCreate(t, fsys, "/trunc"), []byte("some content"))
+
+	f := mustOpen(t, fsys, "/trunc", os.O_RDWR|os.O_TRUNC)
+	got := readAll(t, f)
+	f.Close()
+	if len(got) != 0 {
+		t.Fatalf("content after O_TRUNC open = %q, want empty", got)
+	}
+
+	info, err := fsys.Stat(context.Background(), "/trunc")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf("size after O_TRUNC open = %d, want 0", info.Size())
+	}
+}
+
+// TruncateNoFile checks that O_TRUNC without O_CREATE on a missing file
+// still reports an error instead of silently creating it.
+func TruncateNoFile(t *testing.T, fsys webdav.FileSystem) {
+	_, err := fsys.OpenFile(context.Background(), "/does-not-exist", os.O_RDWR|os.O_TRUNC, 0644)
+	if err == nil {
+		t.Fatalf("open missing file with O_TRUNC: expected error, got none")
+	}
+}
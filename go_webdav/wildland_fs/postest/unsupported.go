@@ -0,0 +1,22 @@
+package postest
+
+import (
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// SymlinkReadlink is a placeholder: webdav.FileSystem has no Symlink or
+// Readlink method, so there is nothing to exercise through the interface.
+// Kept in All so the suite's coverage list matches go-fuse's posixtest
+// name-for-name, and so a future symlink extension to webdav.FileSystem
+// has an obvious place to fill this in.
+func SymlinkReadlink(t *testing.T, fsys webdav.FileSystem) {
+	t.Skip("webdav.FileSystem has no symlink support to test")
+}
+
+// Fallocate is a placeholder for the same reason: webdav.FileSystem has no
+// fallocate-style preallocation call.
+func Fallocate(t *testing.T, fsys webdav.FileSystem) {
+	t.Skip("webdav.FileSystem has no fallocate equivalent to test")
+}
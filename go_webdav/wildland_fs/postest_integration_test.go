@@ -0,0 +1,28 @@
+//go:build integration
+
+package wildland_fs
+
+import (
+	"sync"
+	"testing"
+
+	"wildland.io/webdav_frontend/wildland_fs/postest"
+)
+
+// TestPostestAgainstRealWorker runs the same compliance suite as
+// TestPostestAgainstFakeBridge, but against an actual wildland.go.fs
+// worker process. It requires a working Wildland/Python environment, so it
+// is gated behind the "integration" build tag:
+//
+//	go test -tags=integration ./wildland_fs/...
+func TestPostestAgainstRealWorker(t *testing.T) {
+	fsys := WildlandFS(DefaultSocketPath)
+	fsys.Start(new(sync.WaitGroup))
+
+	for name, test := range postest.All {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, fsys)
+		})
+	}
+}
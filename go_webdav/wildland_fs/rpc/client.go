@@ -0,0 +1,228 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	initialBackoff = 200 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// ErrNotConnected is returned by Call when the client has no live
+// connection to the worker (e.g. it is between reconnect attempts).
+var ErrNotConnected = errors.New("rpc: not connected")
+
+// ErrClosed is returned once the client has been explicitly closed.
+var ErrClosed = errors.New("rpc: client closed")
+
+// pendingCall is a Call awaiting a reply, tagged with the generation of the
+// connection it was sent on so a dropped connection only fails the calls
+// that were actually sent on it, not calls registered against a connection
+// that has since replaced it.
+type pendingCall struct {
+	ch  chan *Response
+	gen uint64
+}
+
+// Client is a JSON-RPC client for a single Unix socket endpoint. It owns a
+// background goroutine that reads replies off the wire and demuxes them to
+// the channel of whichever Call is waiting on that ID, and reconnects with
+// backoff whenever the connection drops so that restarting the worker does
+// not take the rest of the process down with it.
+type Client struct {
+	socketPath string
+
+	// writeMu serializes writes to conn so that concurrent Call()s cannot
+	// interleave their length-prefix and body writes on the wire.
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	conn    net.Conn
+	gen     uint64
+	pending map[uint64]*pendingCall
+	closed  bool
+
+	nextID uint64
+}
+
+// NewClient creates a Client for the worker listening on socketPath. Call
+// Connect to establish the first connection.
+func NewClient(socketPath string) *Client {
+	return &Client{
+		socketPath: socketPath,
+		pending:    make(map[uint64]*pendingCall),
+	}
+}
+
+// Connect dials the worker socket and starts the background reader. It can
+// be called again after Close to reuse the Client for a fresh connection.
+func (c *Client) Connect() error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.closed = false
+	c.gen++
+	gen := c.gen
+	c.mu.Unlock()
+	go c.readLoop(conn, gen)
+	return nil
+}
+
+// Call invokes method with params marshaled as the request params, and
+// unmarshals the result into result (if non-nil). It blocks until a
+// matching reply arrives or the connection is dropped.
+func (c *Client) Call(method string, params interface{}, result interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	id := atomic.AddUint64(&c.nextID, 1)
+	replyCh := make(chan *Response, 1)
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	conn := c.conn
+	if conn == nil {
+		c.mu.Unlock()
+		return ErrNotConnected
+	}
+	gen := c.gen
+	c.pending[id] = &pendingCall{ch: replyCh, gen: gen}
+	c.mu.Unlock()
+
+	req := &Request{ID: id, Method: method, Params: raw}
+	c.writeMu.Lock()
+	err = writeMessage(conn, req)
+	c.writeMu.Unlock()
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+
+	resp := <-replyCh
+	if resp == nil {
+		return ErrNotConnected
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result != nil && resp.Result != nil {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
+
+// Connected reports whether the client currently holds a live connection
+// to the worker.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.closed && c.conn != nil
+}
+
+// Close shuts down the connection and stops any in-flight reconnect loop.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	conn := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+func (c *Client) readLoop(conn net.Conn, gen uint64) {
+	reader := bufio.NewReader(conn)
+	for {
+		resp, err := readMessage(reader)
+		if err != nil {
+			log.Println("rpc: connection lost:", err)
+			c.dropConn(conn, gen, err)
+			return
+		}
+		c.deliver(resp)
+	}
+}
+
+func (c *Client) deliver(resp *Response) {
+	c.mu.Lock()
+	p, ok := c.pending[resp.ID]
+	if ok {
+		delete(c.pending, resp.ID)
+	}
+	c.mu.Unlock()
+	if ok {
+		p.ch <- resp
+	}
+}
+
+// dropConn fails the pending calls that were sent on dead and, unless the
+// client has been explicitly closed, starts reconnecting with backoff. It
+// only touches pending entries tagged with dead's own generation, so a call
+// registered against a connection that replaced dead in the meantime (e.g. a
+// reconnect that raced with dead's readLoop noticing the error) is left
+// alone to be delivered by the new connection's readLoop.
+func (c *Client) dropConn(dead net.Conn, gen uint64, _ error) {
+	c.mu.Lock()
+	closed := c.closed
+	if c.conn == dead {
+		c.conn = nil
+	}
+	var failed []chan *Response
+	for id, p := range c.pending {
+		if p.gen == gen {
+			failed = append(failed, p.ch)
+			delete(c.pending, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, ch := range failed {
+		ch <- nil
+	}
+
+	if !closed {
+		go c.reconnectWithBackoff()
+	}
+}
+
+func (c *Client) reconnectWithBackoff() {
+	backoff := initialBackoff
+	for {
+		time.Sleep(backoff)
+
+		c.mu.Lock()
+		closed := c.closed
+		c.mu.Unlock()
+		if closed {
+			return
+		}
+
+		if err := c.Connect(); err == nil {
+			log.Println("rpc: reconnected to", c.socketPath)
+			return
+		}
+		if backoff < maxBackoff {
+			backoff *= 2
+		}
+	}
+}
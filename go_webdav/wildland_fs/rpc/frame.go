@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+)
+
+// readMessage reads one length-prefixed JSON-RPC response from r.
+func readMessage(r *bufio.Reader) (*Response, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	resp := new(Response)
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// writeMessage writes one length-prefixed JSON-RPC request to w.
+func writeMessage(w io.Writer, req *Request) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var lenPrefix [4]byte
+	binary.BigEndian.PutUint32(lenPrefix[:], uint32(len(body)))
+	if _, err := w.Write(lenPrefix[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,32 @@
+// Package rpc implements the length-prefixed JSON-RPC 2.0 dialect spoken
+// between the Go WebDAV frontend and the Python wildland.go.fs worker over
+// a Unix socket. It knows nothing about Wildland semantics: callers marshal
+// their own params/results, this package only frames and demuxes messages.
+package rpc
+
+import "encoding/json"
+
+// Request is a single JSON-RPC 2.0 call, framed with a 4-byte big-endian
+// length prefix before the JSON body.
+type Request struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is the reply to a Request, matched back to its caller by ID.
+type Response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC 2.0 error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
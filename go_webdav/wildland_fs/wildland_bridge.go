@@ -1,196 +1,226 @@
 package wildland_fs
 
-// #cgo pkg-config: python3-embed
-// #include <Python.h>
-//
-// // C macros need to be wrapped in C functions to be exposed to GO! God bless you, Google!
-// void decref(PyObject *o) { Py_DECREF(o); }
-// void incref(PyObject *o) { Py_INCREF(o); }
-// void tuple_set_item(PyObject *t, int i, PyObject *v) { PyTuple_SET_ITEM(t,i,v); }
-import "C"
-import "unsafe"
-import "errors"
-import "log"
-import "os"
-import "golang.org/x/text/encoding/unicode/utf32"
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	wire "wildland.io/webdav_frontend/wildland_fs/rpc"
+)
 
 /*
-This is the bridge to Python code. No Python awareness allowed
-in other files.
+This is the bridge to the Python side. No Python awareness allowed in other
+files: everything below speaks JSON-RPC 2.0 over a Unix socket to a
+separately-launched `wildland.go.fs` worker process, so the frontend never
+embeds a CPython interpreter and a worker restart does not take the WebDAV
+server down with it.
 */
 
-type PyObjRef *C.struct__object
+const dialRetryInterval = 100 * time.Millisecond
+const dialTimeout = 10 * time.Second
 
+// DefaultSocketPath is the Unix socket the wildland.go.fs worker listens on
+// when the frontend doesn't override it. It is also the path the frontend's
+// termination handler cleans up on shutdown, so it must stay the single
+// source of truth for callers that don't pass their own socketPath.
+const DefaultSocketPath = "/tmp/wlfuse.sock"
 
 type wildland struct {
-	imports map[string]PyObjRef
-	save *C.PyThreadState
-	fspy PyObjRef
-	fs *wildland_fs
+	socketPath string
+	client     *wire.Client
+	worker     *exec.Cmd
+	fs         *wildland_fs
 }
 
-func newPyObject(module PyObjRef, class string, args... PyObjRef) PyObjRef {
-	cClass := C.CString(class)
-	defer C.free(unsafe.Pointer(cClass))
+// Entry describes one directory entry or stat result as reported by the
+// Python worker.
+type Entry struct {
+	Name string      `json:"name"`
+	Mode os.FileMode `json:"mode"`
+	Size int64       `json:"size"`
+}
 
-	pyname := C.PyUnicode_FromString(cClass)
-	defer C.decref(pyname)
+type readdirParams struct {
+	Path   string `json:"path"`
+	Offset int    `json:"offset"`
+}
 
-	moduleDict := C.PyModule_GetDict(module)
-	classObj := C.PyDict_GetItem(moduleDict, pyname)
-	tupleArgs := C.PyTuple_New(C.long(len(args)))
-	for i, arg := range args {
-		C.tuple_set_item(tupleArgs, C.int(i), arg)
-	}
+type statParams struct {
+	Path string `json:"path"`
+}
 
-	defer C.decref(tupleArgs)
-	log.Println("class for ", class, " is ", classObj)
-	inst := C.PyObject_Call(classObj, tupleArgs, nil)
+type openParams struct {
+	Path  string `json:"path"`
+	Flags int    `json:"flags"`
+}
 
-	return inst
+type openResult struct {
+	Handle uint64 `json:"handle"`
 }
 
-func callPyMethod(pyfs PyObjRef, methodName string, args ...PyObjRef) (PyObjRef,error) {
-	cMethodName := C.CString(methodName)
-	defer C.free(unsafe.Pointer(cMethodName))
-	
-	method := C.PyObject_GetAttrString(pyfs, cMethodName)
-	targs := C.PyTuple_New(C.long(len(args)))
-	for i,v := range args {
-		C.tuple_set_item(targs, C.int(i), v)
-	}
-	defer C.decref(targs)
-	dargs := C.PyDict_New()
-	defer C.decref(dargs)
-	res := C.PyObject_Call(method, targs, dargs)
-	return res, nil // no error handling for now ;(
-}
-
-func (wl *wildland)importModule(name string) (PyObjRef,error) {
-	if mod,ok := wl.imports[name]; ok {
-		return mod, nil
-	} else {
-		cname := C.CString(name)
-		defer C.free(unsafe.Pointer(cname))
-		log.Println("going to import Python module ", name)
-		modref := C.PyImport_ImportModule(cname)
-		if(modref != nil) {
-			return modref, nil
-		} else {
-			return nil, errors.New("unable to import module")
-		}
-	}
+type readParams struct {
+	Handle uint64 `json:"handle"`
+	Offset int64  `json:"offset"`
+	Size   int    `json:"size"`
 }
 
-func initWildland() (*wildland, error) {
-	wl := new(wildland)
-	err := wl.setupEnvironment()
-	return wl, err
+type readResult struct {
+	Data []byte `json:"data"`
 }
 
-func (wl *wildland)setupEnvironment() error {
-	/* Could insert venv site packages into PYTHONPATH maybe (needed for
-           MacOS) */
-	
-	return nil
+type writeParams struct {
+	Handle uint64 `json:"handle"`
+	Offset int64  `json:"offset"`
+	Data   []byte `json:"data"`
+}
+
+type writeResult struct {
+	Written int `json:"written"`
 }
 
+type handleParams struct {
+	Handle uint64 `json:"handle"`
+}
 
+type mkdirParams struct {
+	Path string `json:"path"`
+}
 
-func (wl *wildland)start() error {
-	C.Py_Initialize()
-//	defer C.Py_Finalize()
+type renameParams struct {
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+}
 
-	goSocketPath := os.Getenv("XDG_RUNTIME_DIR") + "wlfuse.sock"
-	log.Println("using control_server socket path: ", goSocketPath)
-	socketPath := C.CString(goSocketPath)
-	pySocketPath := C.PyUnicode_FromString(socketPath)
-	defer C.free(unsafe.Pointer(socketPath))
-	defer C.decref(pySocketPath)
+type unlinkParams struct {
+	Path string `json:"path"`
+}
 
-	log.Println("going to dump module search path now")
-	envdumpcmd := C.CString("import sys; print(sys.path);")
-	C.PyRun_SimpleString(envdumpcmd)
-	C.free(unsafe.Pointer(envdumpcmd))
-	log.Println("just dumped module search path")
+func initWildland(socketPath string) (*wildland, error) {
+	/* Could insert venv site packages into PYTHONPATH maybe (needed for
+	   MacOS) */
 
-	mod,err := wl.importModule("wildland.go.fs")
-	if err != nil {
-		C.PyErr_Print()
-		return err
-	}
+	wl := new(wildland)
+	wl.socketPath = socketPath
+	return wl, nil
+}
 
-	pyfs := newPyObject(mod, "WildlandGoFS", pySocketPath)
-	if pyfs == nil {
-		C.PyErr_Print()
-		return errors.New("failed to instantiate python fs")
-	} else {
-		//defer C.decref(pyfs) - leaving immeadiately we need to keep this object alive
-		log.Println("invoking fs.start()")
-		obj,_ := callPyMethod(pyfs, "start")
-		C.decref(obj)
-		log.Println("fs.start() invoked")
+// start launches the Python wildland.go.fs worker and connects to it over
+// socketPath, retrying until the worker has had time to create the socket.
+func (wl *wildland) start() error {
+	os.Remove(wl.socketPath)
+
+	log.Println("launching wildland.go.fs worker, socket path:", wl.socketPath)
+	wl.worker = exec.Command("python3", "-m", "wildland.go.fs", wl.socketPath)
+	wl.worker.Stdout = os.Stdout
+	wl.worker.Stderr = os.Stderr
+	if err := wl.worker.Start(); err != nil {
+		return fmt.Errorf("failed to launch wildland.go.fs worker: %w", err)
 	}
-	wl.fspy = pyfs
-	wait_chan := make(chan struct{})
-	log.Println("I'm leaving the scope of bridge now!!!")
-	wl.save = C.PyEval_SaveThread()
-	<- wait_chan
-	return nil
-}
-
-func (wl *wildland)readdir(path string) ([]i_fs_object, error) {
-	C.PyEval_RestoreThread(wl.save)
-	defer func(){
-		wl.save = C.PyEval_SaveThread()
-	}()
-	
-	pyName := makePyString(path)
-	defer C.decref(pyName)
-	pyoffset := makePyLong(0)
-	defer C.decref(pyoffset)
-	
-	res, err := callPyMethod(wl.fspy, "readdir", pyName, pyoffset)
-	var rv []i_fs_object = nil
-	if res != nil {
-		defer C.decref(res)
-		nelts := int(C.PySequence_Size(res))
-		rv = make([]i_fs_object, 0, nelts)
-		for i := 0 ;i < nelts; i++ {
-			pyobj := C.PySequence_GetItem(res, C.long(i))
-			ucs4chars := C.PyUnicode_GetLength(pyobj)
-			ucs4str := C.PyUnicode_AsUCS4Copy(pyobj)
-//			defer C.free(unsafe.Pointer(ucs4str))
-			enc := utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM)
-			dec := enc.NewDecoder()
-			gobytes := C.GoBytes(unsafe.Pointer(ucs4str), C.int(ucs4chars * C.long(4)))
-			if utf8bytes, err := dec.Bytes(gobytes); err == nil {
-				name := string(utf8bytes[:])
-				obj := &wildland_dir { fs_object: fs_object {name: name} } // TODO: should be either file or dir composition
-				obj.SetFS(wl.fs)
-				wl.fs.pmap["/" + name] = obj
-				log.Println("registering path ", name)
-				rv = append(rv, obj)
-			} else {
-				return nil, err
-			}
+
+	wl.client = wire.NewClient(wl.socketPath)
+	deadline := time.Now().Add(dialTimeout)
+	var err error
+	for time.Now().Before(deadline) {
+		if err = wl.client.Connect(); err == nil {
+			log.Println("connected to wildland.go.fs worker")
+			return nil
 		}
+		time.Sleep(dialRetryInterval)
 	}
-	
-	return rv,err
+	return fmt.Errorf("timed out connecting to wildland.go.fs worker: %w", err)
 }
 
+// call forwards to the bridge's RPC client, reporting wire.ErrNotConnected
+// instead of dereferencing a nil client when start() hasn't connected yet
+// (or hasn't reconnected since a drop).
+func (wl *wildland) call(method string, params interface{}, result interface{}) error {
+	if wl.client == nil {
+		return wire.ErrNotConnected
+	}
+	return wl.client.Call(method, params, result)
+}
+
+func (wl *wildland) readdir(path string) ([]Entry, error) {
+	var entries []Entry
+	err := wl.call("readdir", readdirParams{Path: path, Offset: 0}, &entries)
+	return entries, err
+}
+
+func (wl *wildland) stat(path string) (Entry, error) {
+	var entry Entry
+	err := wl.call("stat", statParams{Path: path}, &entry)
+	return entry, err
+}
+
+func (wl *wildland) open(path string, flags int) (uint64, error) {
+	var res openResult
+	err := wl.call("open", openParams{Path: path, Flags: flags}, &res)
+	return res.Handle, err
+}
+
+func (wl *wildland) read(handle uint64, offset int64, size int) ([]byte, error) {
+	var res readResult
+	err := wl.call("read", readParams{Handle: handle, Offset: offset, Size: size}, &res)
+	return res.Data, err
+}
+
+func (wl *wildland) write(handle uint64, offset int64, data []byte) (int, error) {
+	var res writeResult
+	err := wl.call("write", writeParams{Handle: handle, Offset: offset, Data: data}, &res)
+	return res.Written, err
+}
 
-func makePyString(str string) PyObjRef {
-	cstr := C.CString(str)
-	defer C.free(unsafe.Pointer(cstr))
-	return C.PyUnicode_FromString(cstr)
+func (wl *wildland) close(handle uint64) error {
+	return wl.call("close", handleParams{Handle: handle}, nil)
 }
 
-func makePyLong(val int) PyObjRef {
-	obj := C.PyLong_FromLong(C.long(val))
-	if val >= -5 && val <= 256 {
-		C.incref(obj)
+func (wl *wildland) mkdir(path string) error {
+	return wl.call("mkdir", mkdirParams{Path: path}, nil)
+}
+
+func (wl *wildland) rename(oldPath, newPath string) error {
+	return wl.call("rename", renameParams{OldPath: oldPath, NewPath: newPath}, nil)
+}
+
+func (wl *wildland) unlink(path string) error {
+	return wl.call("unlink", unlinkParams{Path: path}, nil)
+}
+
+// reconnect forces a fresh connection to the worker, e.g. after the admin
+// endpoint asks for one.
+func (wl *wildland) reconnect() error {
+	wl.client.Close()
+	return wl.client.Connect()
+}
+
+// Errno codes used by the wildland.go.fs worker in its JSON-RPC error
+// objects, mirroring the POSIX errno values they stand for.
+const (
+	rpcErrNotExist   = 2
+	rpcErrExist      = 17
+	rpcErrPermission = 13
+)
+
+// mapBridgeError translates a Wildland-side error into the syscall error
+// WebDAV expects, so clients see 404/409/403 instead of a blanket 500.
+func mapBridgeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var rpcErr *wire.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case rpcErrNotExist:
+			return syscall.ENOENT
+		case rpcErrExist:
+			return syscall.EEXIST
+		case rpcErrPermission:
+			return syscall.EACCES
+		}
 	}
-	return obj
+	return err
 }
@@ -1,13 +1,18 @@
 package wildland_fs
 
 import (
-	"fmt"; "log"; "errors"; "context"; "os"; "io/fs"; "time";
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
 	"syscall"
+
 	"golang.org/x/net/webdav"
-	"sync"
 )
 
-
 type i_fs_object interface {
 	os.FileInfo
 	webdav.File
@@ -16,26 +21,22 @@ type i_fs_object interface {
 }
 
 type wildland_fs struct {
-	pmap map[string]i_fs_object
-	wl *wildland
+	root *Inode
+	wl   *wildland
 }
 
-type wildland_file struct {
-	name string
-}
-
-
-func (fs *wildland_fs)init() {
+func (fs *wildland_fs) init(socketPath string) {
 	fmt.Println("initializing")
-	fs.pmap = make(map[string]i_fs_object)
 
 	root_dir := new(wildland_dir)
 	root_dir.name = "/"
 	root_dir.SetFS(fs)
-	
-	fs.pmap["/"] = root_dir
-	wlref,err := initWildland()
-	if(err != nil) {
+
+	fs.root = NewPersistentInode(root_dir, StableAttr{Mode: os.ModeDir | 0555})
+	root_dir.ino = fs.root
+
+	wlref, err := initWildland(socketPath)
+	if err != nil {
 		log.Fatal(err)
 	} else {
 		fs.wl = wlref
@@ -43,7 +44,7 @@ func (fs *wildland_fs)init() {
 	}
 }
 
-func (fs *wildland_fs)Start(wg *sync.WaitGroup) {
+func (fs *wildland_fs) Start(wg *sync.WaitGroup) {
 	defer func() {
 		err := recover()
 		log.Println(err)
@@ -53,88 +54,234 @@ func (fs *wildland_fs)Start(wg *sync.WaitGroup) {
 		log.Fatal("wildland failed to start", err)
 	}
 	log.Println("wl start() exit")
-//	wg.Done()
+	//	wg.Done()
 }
 
-
-func WildlandFS() *wildland_fs {
+// WildlandFS builds the filesystem and its bridge for the worker listening
+// on socketPath. It does not connect: Start launches the worker and
+// connects the bridge to it.
+func WildlandFS(socketPath string) *wildland_fs {
 	rv := new(wildland_fs)
-	rv.init()
+	rv.init(socketPath)
 	return rv
 }
 
-func (fs *wildland_fs)Bless(object *fs_object) {
+func (fs *wildland_fs) Bless(object *fs_object) {
 	object.SetFS(fs)
 }
 
-func (fs *wildland_fs)Readdir(path string)([]i_fs_object, error) {
-	log.Println("wildand readdir called for path: ", path, fs.wl)
-	return fs.wl.readdir(path)
+// lookup walks the inode tree from root to path, lazily filling in any
+// directory level that has not been listed yet.
+func (fs *wildland_fs) lookup(path string) (*Inode, error) {
+	if path == "" || path == "/" {
+		return fs.root, nil
+	}
+
+	cur := fs.root
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		child := cur.GetChild(part)
+		if child == nil {
+			if _, err := fs.Readdir(cur.Path(fs.root)); err != nil {
+				return nil, err
+			}
+			child = cur.GetChild(part)
+		}
+		if child == nil {
+			return nil, syscall.ENOENT
+		}
+		cur = child
+	}
+	return cur, nil
+}
+
+// parentOf resolves the directory Inode holding name and the leaf name
+// within it, e.g. for use by OpenFile/RemoveAll/Rename to update the
+// cached tree alongside the corresponding bridge call.
+func (fs *wildland_fs) parentOf(name string) (*Inode, string, error) {
+	dir, base := path.Split(name)
+	parentIno, err := fs.lookup(strings.TrimSuffix(dir, "/"))
+	if err != nil {
+		return nil, "", err
+	}
+	return parentIno, base, nil
+}
+
+// newChild builds the Inode (and backing wildland_dir/wildland_file) for
+// a directory entry reported by the bridge, using entry.Mode to decide
+// which one it is.
+func (fs *wildland_fs) newChild(entry Entry) *Inode {
+	if entry.Mode.IsDir() {
+		dir := &wildland_dir{fs_object: fs_object{name: entry.Name}}
+		dir.SetFS(fs)
+		ino := NewPersistentInode(dir, StableAttr{Mode: entry.Mode})
+		dir.ino = ino
+		return ino
+	}
+
+	file := &wildland_file{fs_object: fs_object{name: entry.Name}, size: entry.Size}
+	file.SetFS(fs)
+	ino := NewPersistentInode(file, StableAttr{Mode: entry.Mode})
+	file.ino = ino
+	return ino
 }
 
+func (fs *wildland_fs) Readdir(path string) ([]i_fs_object, error) {
+	log.Println("wildand readdir called for path: ", path, fs.wl)
+
+	dirIno, err := fs.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fs.wl.readdir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(entries))
+	rv := make([]i_fs_object, 0, len(entries))
+	for _, entry := range entries {
+		seen[entry.Name] = true
+		child := dirIno.GetChild(entry.Name)
+		if child == nil {
+			child = fs.newChild(entry)
+			dirIno.AddChild(entry.Name, child)
+		}
+		log.Println("registering path ", entry.Name)
+		rv = append(rv, child.ops)
+	}
+
+	// Drop any cached child the bridge no longer reports, e.g. one removed
+	// by a concurrent writer on the same Wildland container rather than
+	// through this process's own RemoveAll/Rename.
+	for name := range dirIno.Children() {
+		if !seen[name] {
+			dirIno.RmChild(name)
+		}
+	}
+
+	return rv, nil
+}
 
 // Compliance with FileSystem interface, as stated here:
 // https://github.com/golang/net/blob/e898025ed96aa6d08e98132b8dca210e9e7a0cd2/webdav/file.go#L40
-func (fs *wildland_fs)Mkdir(ctx context.Context, name string, 
+func (fs *wildland_fs) Mkdir(ctx context.Context, name string,
 	perm os.FileMode) error {
 	log.Println("Mkdir", name)
-	return errors.New("i am not implemented")
+	return mapBridgeError(fs.wl.mkdir(name))
 }
 
-func (fs *wildland_fs)OpenFile(ctx context.Context, name string,
+func (fs *wildland_fs) OpenFile(ctx context.Context, name string,
 	flag int, perm os.FileMode) (webdav.File, error) {
 	log.Println("Open", name)
-	if obj, ok := fs.pmap[name]; ok {
-		return obj, nil
-	} else {
-		return nil, syscall.ENOENT
+
+	if ino, err := fs.lookup(name); err == nil {
+		file, ok := ino.ops.(*wildland_file)
+		if !ok {
+			return ino.ops, nil // directories have no bridge handle to open
+		}
+		handle, err := fs.wl.open(name, flag)
+		if err != nil {
+			return nil, mapBridgeError(err)
+		}
+		return &wildland_file{fs_object: file.fs_object, handle: handle, size: file.size}, nil
+	} else if err != syscall.ENOENT || flag&os.O_CREATE == 0 {
+		return nil, err
 	}
-}
 
-func (fs *wildland_fs)RemoveAll(ctx context.Context, name string) error {
-	log.Println("RemoveAll", name)
-	return errors.New("nonono")
-}
+	// name does not exist yet but the caller asked for O_CREATE: create
+	// it on the Wildland side and register a fresh Inode for it.
+	handle, err := fs.wl.open(name, flag)
+	if err != nil {
+		return nil, mapBridgeError(err)
+	}
 
-func (fs *wildland_fs)Rename(ctx context.Context, old, new string) error {
-	log.Println("Rename", old)
-	return errors.New("i am not here")
+	child := &wildland_file{fs_object: fs_object{name: path.Base(name)}}
+	child.SetFS(fs)
+	childIno := NewPersistentInode(child, StableAttr{})
+	child.ino = childIno
+
+	if parentIno, base, perr := fs.parentOf(name); perr == nil {
+		parentIno.AddChild(base, childIno)
+	}
+
+	return &wildland_file{fs_object: child.fs_object, handle: handle}, nil
 }
 
-func (fs *wildland_fs)Stat(ctx context.Context, name string) (os.FileInfo, error) {
-	log.Println("Stat", name)
-	if obj, ok := fs.pmap[name]; ok {
-		return obj, nil
-	} else {
-		return nil, syscall.ENOENT
+func (fs *wildland_fs) RemoveAll(ctx context.Context, name string) error {
+	log.Println("RemoveAll", name)
+	if err := mapBridgeError(fs.wl.unlink(name)); err != nil {
+		return err
 	}
+	if parentIno, base, err := fs.parentOf(name); err == nil {
+		parentIno.RmChild(base)
+	}
+	return nil
 }
 
+func (fs *wildland_fs) Rename(ctx context.Context, old, new string) error {
+	log.Println("Rename", old)
+	if err := mapBridgeError(fs.wl.rename(old, new)); err != nil {
+		return err
+	}
 
-func (f wildland_file) Name() string {
-	return f.name
-}
+	oldParent, oldBase, err := fs.parentOf(old)
+	if err != nil {
+		return nil // bridge already committed the rename; the tree will repopulate lazily
+	}
+	moved := oldParent.RmChild(oldBase)
+	if moved == nil {
+		return nil
+	}
 
-func (d wildland_dir) Name() string {
-	return d.name
+	newParent, newBase, err := fs.parentOf(new)
+	if err != nil {
+		return nil
+	}
+	newParent.RmChild(newBase) // drop any stale cached entry at the destination
+	renameOps(moved.ops, newBase)
+	newParent.AddChild(newBase, moved)
+	return nil
 }
 
-func (f wildland_file) IsDir() bool {
-	return false
+// renameOps updates the leaf name stored on a node's backing object after
+// it moves to a new parent/name in the tree.
+func renameOps(ops FSNode, newBase string) {
+	switch o := ops.(type) {
+	case *wildland_dir:
+		o.name = newBase
+	case *wildland_file:
+		o.name = newBase
+	}
 }
 
-func (f wildland_file) Size() int64 {
-	return 0
+// Invalidate evicts name from the cached inode tree, so the next lookup
+// re-fetches it from Wildland instead of trusting the cached entry.
+func (fs *wildland_fs) Invalidate(name string) {
+	if name == "" || name == "/" {
+		return
+	}
+	if parentIno, base, err := fs.parentOf(name); err == nil {
+		parentIno.RmChild(base)
+	}
 }
 
-func (f wildland_file) Mode() fs.FileMode {
-	return 0555 | os.ModeDir
+// Reconnect forces a fresh connection to the Wildland bridge.
+func (fs *wildland_fs) Reconnect() error {
+	return fs.wl.reconnect()
 }
 
-func (f wildland_file) ModTime() time.Time {
-	return time.Now()
+// Healthy reports whether the Wildland bridge currently has a live
+// connection.
+func (fs *wildland_fs) Healthy() bool {
+	return fs.wl != nil && fs.wl.client != nil && fs.wl.client.Connected()
 }
 
-func (f wildland_file) Sys() interface{} {
-	return nil
+func (fs *wildland_fs) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	log.Println("Stat", name)
+	ino, err := fs.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return ino.ops.Stat()
 }